@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2019-present Sonatype, Inc. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+import java.util.concurrent.ExecutionException
+import java.util.concurrent.ExecutorService
+import java.util.concurrent.FutureTask
+
+import javax.net.ssl.SSLException
+import javax.net.ssl.SSLHandshakeException
+import javax.net.ssl.SSLPeerUnverifiedException
+
+// helpers of NexusPublisher.groovy that depend on no setting or state of a run, so tests can load them on their own.
+// The script binds them under the same names
+class NexusSupport {
+
+  // coordinates and attributes naming a repository path, normalized by toRepositoryPath before they are sent
+  static final List<String> repositoryPathKeys = ['directory', 'filename']
+
+  // behavior of each repository format: the multipart field of the asset at an index, the repository path of an asset
+  // or null when only Nexus knows it, and the recipe and attributes of a hosted repository created for it. A new format
+  // only needs an entry here where it differs from the defaults of formatOf
+  static final Map formats = [
+    maven2: [assetField: { int index -> "maven2.asset${index + 1}" },
+             assetPath: { coordinates, attributes, File file -> toMavenPath(coordinates, attributes, file) },
+             recipe: 'maven', repositoryAttributes: [maven: [versionPolicy: 'MIXED', layoutPolicy: 'STRICT']]],
+    raw: [assetField: { int index -> "raw.asset${index + 1}" },
+          assetPath: { coordinates, attributes, File file ->
+            [toRepositoryPath(coordinates.directory), toRepositoryPath(attributes.filename) ?: file.name].findAll().join('/')
+          }],
+    yum: [repositoryAttributes: [yum: [repodataDepth: 0, deployPolicy: 'STRICT']]]
+  ]
+
+  // look up the behavior of a format, formats without an entry have a single asset
+  static Map formatOf(String format) {
+    [assetField: { int index -> "${format}.asset" }, assetPath: { coordinates, attributes, File file -> null },
+     recipe: format, repositoryAttributes: [:]] + (formats[format] ?: [:])
+  }
+
+  // build the maven2 repository path of an asset
+  static String toMavenPath(coordinates, attributes, File file) {
+    def classifier = attributes.classifier ? "-${attributes.classifier}" : ''
+    def extension = attributes.extension ?: file.name.substring(file.name.lastIndexOf('.') + 1)
+    ("${coordinates.groupId.replace('.', '/')}/${coordinates.artifactId}/${coordinates.version}/" +
+        "${coordinates.artifactId}-${coordinates.version}${classifier}.${extension}").toString()
+  }
+
+  // render a path template, replacing {{ name }} placeholders with the coordinates and asset attributes, the name,
+  // extension and path (below an uploaded directory) of the file, build.* values of the build and env.* variables.
+  // Unknown placeholders fail, so a typo does not upload to an unexpected path
+  static String renderPath(String template, Map coordinates, Map attributes, File file, String path, Map build) {
+    def values = coordinates + attributes + [name: file.name, path: path,
+                                             extension: file.name.contains('.') ? file.name.substring(file.name.lastIndexOf('.') + 1) : '']
+    toRepositoryPath(template.replaceAll(/\{\{\s*([\w.-]+)\s*\}\}/) { match, String key ->
+      def value = key.startsWith('build.') ? build[key.substring(6)] : key.startsWith('env.') ? System.getenv(key.substring(4)) :
+          values[key]
+      if (value == null) {
+        throw new IllegalArgumentException("Unknown placeholder {{ ${key} }} in path template ${template}")
+      }
+      value.toString()
+    })
+  }
+
+  // normalize a directory or file name given as coordinate or attribute to a repository path, with forward slashes
+  // also when it was written for Windows and without leading or trailing ones
+  static String toRepositoryPath(String path) {
+    path?.replace('\\', '/')?.replaceAll('^/+|/+$', '')
+  }
+
+  // tell why a repository path given as coordinate or attribute could write outside the intended directory, returns
+  // null for a safe path
+  static String unsafeRepositoryPath(String path) {
+    if (path =~ /\p{Cntrl}/) {
+      return 'contains control characters'
+    }
+    toRepositoryPath(path).tokenize('/').any { it == '..' } ? 'leads outside its directory with ..' : null
+  }
+
+  // convert an artifact path to one of the local file system, whose separator is given: backslashes of paths written
+  // for Windows agents separate directories elsewhere too, and on Windows the /c/ drive paths of Git Bash name the drive
+  static String artifactPath(String path, char separator = File.separatorChar) {
+    if (separator == ('/' as char)) {
+      return path.replace('\\', '/')
+    }
+    path ==~ /\/[a-zA-Z]\/.*/ ? "${path[1]}:${path.substring(2)}".toString() : path
+  }
+
+  // describe a multipart form body of fields with a name, a value and optionally a filename, where File values are
+  // streamed from the file. Every write opens the files again, through open when given, so a retried request sends
+  // their complete content rather than what the failed attempt left of a stream
+  static Map multipartBody(List fields) {
+    def boundary = UUID.randomUUID().toString()
+    def heads = fields.collect {
+      ("--${boundary}\r\nContent-Disposition: form-data; name=\"${it.name}\"" +
+          (it.filename ? "; filename=\"${it.filename}\"\r\nContent-Type: application/octet-stream" : '') + '\r\n\r\n').getBytes('UTF-8')
+    }
+    def valueLength = { it instanceof File ? it.length() : it.toString().getBytes('UTF-8').length }
+    def newline = '\r\n'.getBytes('UTF-8')
+    def tail = "--${boundary}--\r\n".getBytes('UTF-8')
+    [contentType: "multipart/form-data; boundary=${boundary}".toString(),
+     length: tail.length + (0..<fields.size()).sum(0L) { heads[it].length + valueLength(fields[it].value) + newline.length },
+     write: { OutputStream out, Closure open = { File file -> file.newInputStream() } ->
+       fields.eachWithIndex { field, index ->
+         out.write(heads[index])
+         if (field.value instanceof File) {
+           open(field.value).withStream { out << it }
+         } else {
+           out.write(field.value.toString().getBytes('UTF-8'))
+         }
+         out.write(newline)
+       }
+       out.write(tail)
+     }]
+  }
+
+  // tell whether a failed request is worth retrying: dropped or refused connections, timeouts, connections closed
+  // early and responses with status 408, 429 or 5xx likely succeed later. Anything else, such as other statuses,
+  // failed authentication, untrusted certificates, unknown hosts or missing files, points to a configuration error
+  // a retry would only hide
+  static boolean retryable(IOException e) {
+    if (e instanceof ResponseException) {
+      return e.status in [408, 429] || e.status >= 500
+    }
+    if (e instanceof SSLException) {
+      return !(e instanceof SSLHandshakeException || e instanceof SSLPeerUnverifiedException)
+    }
+    e instanceof SocketException || e instanceof SocketTimeoutException || e instanceof EOFException
+  }
+
+  // parse a size such as 500MB or 5G to bytes
+  static long parseSize(String size) {
+    def matcher = size.trim().toUpperCase() =~ /^(\d+(?:\.\d+)?)\s*([KMGT]?)I?B?$/
+    if (!matcher.matches()) {
+      throw new IllegalArgumentException("Invalid size: ${size}")
+    }
+    (long) (matcher.group(1).toBigDecimal() * (1L << (10 * ' KMGT'.indexOf(matcher.group(2) ?: ' '))))
+  }
+
+  // run an action for each entry of a map on a pool, returns one result per entry in the order of the map however the
+  // actions finish, holding the key and either the value returned by the action or the error it threw
+  static List<Map> inOrder(ExecutorService pool, Map entries, Closure action) {
+    entries.collect { key, value ->
+      def task = new FutureTask({ action(key, value) })
+      pool.execute(task)
+      [key: key, task: task]
+    }.collect {
+      try {
+        [key: it.key, value: it.task.get()]
+      } catch (ExecutionException e) {
+        [key: it.key, error: e.cause]
+      }
+    }
+  }
+}
@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) 2019-present Sonatype, Inc. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// failure uploading an artifact, wrapping its cause, with the status of the response rejecting it or 0 without one
+class UploadException extends IOException {
+  String artifact
+  int status
+
+  UploadException(String artifact, Throwable cause) {
+    super("Upload of ${artifact} failed: ${cause.message}".toString(), cause)
+    this.artifact = artifact
+    this.status = cause instanceof ResponseException ? cause.status : 0
+  }
+}
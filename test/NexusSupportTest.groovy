@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2019-present Sonatype, Inc. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+import java.util.concurrent.Executors
+
+import java.nio.file.NoSuchFileException
+
+import javax.net.ssl.SSLHandshakeException
+
+// tests of the helpers in NexusSupport.groovy, run from the repository root with
+//   groovy -cp . test/NexusSupportTest.groovy
+// A failing assertion ends the run with a non-zero exit code and the values it compared
+
+// only failures likely to succeed later are retried, configuration errors are not hidden by retries
+assert NexusSupport.retryable(new ConnectException('Connection refused'))
+assert NexusSupport.retryable(new SocketTimeoutException('Read timed out'))
+[408, 429, 500, 502, 503, 504].each { assert NexusSupport.retryable(new ResponseException("status ${it}".toString(), it, null)) }
+[400, 401, 403, 404, 409].each { assert !NexusSupport.retryable(new ResponseException("status ${it}".toString(), it, null)) }
+assert !NexusSupport.retryable(new AuthenticationException('no access_token'))
+assert !NexusSupport.retryable(new SSLHandshakeException('PKIX path building failed'))
+assert !NexusSupport.retryable(new FileNotFoundException('dist/app.jar (No such file or directory)'))
+assert !NexusSupport.retryable(new NoSuchFileException('dist/app.jar'))
+assert !NexusSupport.retryable(new UnknownHostException('nexus.example.invalid'))
+assert !NexusSupport.retryable(new MalformedURLException('no protocol: nexus.example.com'))
+
+// results are reported in the order of the artifacts whatever order the parallel uploads finish in, with failures in
+// their place
+pool = Executors.newFixedThreadPool(8)
+try {
+  entries = (1..16).collectEntries { [("file${it}".toString()): 16 - it] }
+  finished = Collections.synchronizedList([])
+  results = NexusSupport.inOrder(pool, entries) { key, delay ->
+    sleep(delay * 20)
+    finished << key
+    if (key == 'file3') {
+      throw new IOException('Connection reset')
+    }
+    key.toUpperCase()
+  }
+  assert finished != (entries.keySet() as List)
+  assert results*.key == (entries.keySet() as List)
+  assert results.find { it.key == 'file3' }.error.message == 'Connection reset'
+  assert results.findAll { !it.error }.every { it.value == it.key.toUpperCase() }
+
+  // many actions failing at the same time all get their result, and all reach a synchronized list they record to
+  failures = Collections.synchronizedList([])
+  results = NexusSupport.inOrder(pool, (1..500).collectEntries { [(it): it] }) { key, value ->
+    sleep(value % 3)
+    if (value % 2) {
+      failures << key
+      throw new IOException("Upload of ${key} failed".toString())
+    }
+    value
+  }
+  assert results*.key == (1..500).toList()
+  assert failures.sort() == (1..500).findAll { it % 2 }
+  assert results.count { it.error } == 250
+} finally {
+  pool.shutdownNow()
+}
+
+// paths written for Windows agents resolve on Linux agents and the other way round
+assert NexusSupport.artifactPath('target\\app-1.0.jar', '/' as char) == 'target/app-1.0.jar'
+assert NexusSupport.artifactPath('\\\\build-server\\share\\app-1.0.jar', '/' as char) == '//build-server/share/app-1.0.jar'
+assert NexusSupport.artifactPath('/c/build/app-1.0.jar', '\\' as char) == 'c:/build/app-1.0.jar'
+assert NexusSupport.artifactPath('C:\\build\\app-1.0.jar', '\\' as char) == 'C:\\build\\app-1.0.jar'
+assert NexusSupport.artifactPath('\\\\build-server\\share\\app-1.0.jar', '\\' as char) == '\\\\build-server\\share\\app-1.0.jar'
+assert NexusSupport.artifactPath('target/app-1.0.jar', '/' as char) == 'target/app-1.0.jar'
+
+// directories and file names given as coordinates or attributes become repository paths with forward slashes
+assert NexusSupport.toRepositoryPath('\\releases\\1.0\\') == 'releases/1.0'
+assert NexusSupport.toRepositoryPath('docs\\api/index.html') == 'docs/api/index.html'
+assert NexusSupport.toRepositoryPath(null) == null
+assert NexusSupport.unsafeRepositoryPath('..\\..\\other') == 'leads outside its directory with ..'
+assert NexusSupport.unsafeRepositoryPath('releases\\1.0') == null
+assert NexusSupport.formatOf('raw').assetPath([directory: 'dist\\v1\\'], [:], new File('app.zip')) == 'dist/v1/app.zip'
+assert NexusSupport.formatOf('raw').assetPath([:], [filename: 'c\\app.zip'], new File('app.zip')) == 'c/app.zip'
+
+// repository paths of the formats and path templates
+assert NexusSupport.formatOf('maven2').assetPath([groupId: 'com.example', artifactId: 'app', version: '1.0'],
+    [classifier: 'sources'], new File('app-sources.jar')) == 'com/example/app/1.0/app-1.0-sources.jar'
+assert NexusSupport.formatOf('npm').assetPath([:], [:], new File('app-1.0.tgz')) == null
+assert NexusSupport.formatOf('npm').assetField(0) == 'npm.asset'
+assert NexusSupport.renderPath('{{ build.branch }}/{{version}}/{{ name }}', [version: '1.0'], [:], new File('app.zip'), 'app.zip',
+    [branch: 'main']) == 'main/1.0/app.zip'
+try {
+  NexusSupport.renderPath('{{ versoin }}/{{ name }}', [version: '1.0'], [:], new File('app.zip'), 'app.zip', [:])
+  assert false, 'an unknown placeholder must fail'
+} catch (IllegalArgumentException e) {
+  assert e.message == 'Unknown placeholder {{ versoin }} in path template {{ versoin }}/{{ name }}'
+}
+
+// sizes
+assert NexusSupport.parseSize('500MB') == 500L * 1024 * 1024
+assert NexusSupport.parseSize('1.5k') == 1536L
+assert NexusSupport.parseSize('2 GiB') == 2L * 1024 * 1024 * 1024
+try {
+  NexusSupport.parseSize('lots')
+  assert false, 'an invalid size must fail'
+} catch (IllegalArgumentException e) {
+  assert e.message == 'Invalid size: lots'
+}
+
+// the length of a multipart body is that of what it writes, also when written again for a retry
+file = File.createTempFile('asset', '.jar')
+file.deleteOnExit()
+file.text = 'content of the asset'
+body = NexusSupport.multipartBody([[name: 'raw.directory', value: 'dist'], [name: 'raw.asset1', filename: 'app.jar', value: file]])
+2.times {
+  def out = new ByteArrayOutputStream()
+  body.write(out)
+  assert out.size() == body.length
+  assert out.toString('UTF-8').contains('content of the asset')
+}
+assert body.contentType.startsWith('multipart/form-data; boundary=')
+
+println 'NexusSupport tests passed'